@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -13,6 +16,10 @@ import (
 	livestream "cloud.google.com/go/video/livestream/apiv1"
 	"cloud.google.com/go/video/livestream/apiv1/livestreampb"
 	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/kesokaj/gcp-livestream-demo/lroutil"
+	"github.com/kesokaj/gcp-livestream-demo/manifest"
+	"github.com/kesokaj/gcp-livestream-demo/switcher"
 )
 
 var runningNumber string = "01"
@@ -22,8 +29,33 @@ var channelID string = "livestream-channel-" + runningNumber
 var inputID string = "livestream-input-" + runningNumber
 var gcsoutput string = "gs://<GCP_STORAGE_BUCKET>/" + inputID
 
-// createInputIfNotExists creates an input endpoint if it does not exist.
-func createInputIfNotExists(w io.Writer, projectID, location, inputID string) error {
+// useMultiInput switches main to createMultiInputChannelIfNotExists, which
+// attaches multiple inputs plus a slate and applies an input-switching
+// schedule instead of creating a single-input channel.
+var useMultiInput bool = false
+var multiInputIDs []string = []string{"livestream-input-01", "livestream-input-02"}
+var slateInputID string = "livestream-input-slate"
+var schedulePath string = "schedule.json"
+
+// statePath is where the manifest of the inputs and channels this tool
+// creates, or finds already present, is persisted, so deleteAll can later
+// tear down only those.
+var statePath string = "state.json"
+
+// newRunID returns a short random identifier used as the manifest's run ID
+// when no manifest exists yet at statePath; an existing manifest keeps the
+// run ID it was saved with.
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "run-unknown"
+	}
+	return "run-" + hex.EncodeToString(b)
+}
+
+// createInputIfNotExists creates an input endpoint if it does not exist,
+// recording it in m on success or when it already exists.
+func createInputIfNotExists(w io.Writer, projectID, location, inputID string, m *manifest.Manifest) error {
 	ctx := context.Background()
 	client, err := livestream.NewClient(ctx)
 	if err != nil {
@@ -39,6 +71,9 @@ func createInputIfNotExists(w io.Writer, projectID, location, inputID string) er
 	existingInput, err := client.GetInput(ctx, reqGet)
 	if err == nil {
 		log.Printf("Input %s already exists.\n", inputName)
+		if err := m.Record(manifest.KindInput, inputName); err != nil {
+			log.Printf("Error updating manifest for input %s: %v\n", inputName, err)
+		}
 		inputInfo := map[string]interface{}{
 			"inputID": inputName,
 			"uri":     existingInput.GetUri(),
@@ -76,12 +111,18 @@ func createInputIfNotExists(w io.Writer, projectID, location, inputID string) er
 	if err != nil {
 		return fmt.Errorf("CreateInput: %w", err)
 	}
-	response, err := op.Wait(ctx)
+	response, err := lroutil.Wait(ctx, w, op, func(cleanupCtx context.Context) error {
+		_, err := client.DeleteInput(cleanupCtx, &livestreampb.DeleteInputRequest{Name: inputName})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("Wait: %w", err)
 	}
 
 	log.Printf("Input created: %s\n", response.Name)
+	if err := m.Record(manifest.KindInput, response.Name); err != nil {
+		log.Printf("Error updating manifest for input %s: %v\n", response.Name, err)
+	}
 	inputInfo := map[string]interface{}{
 		"inputID": response.Name,
 		"uri":     response.GetUri(),
@@ -108,7 +149,7 @@ func createInputIfNotExists(w io.Writer, projectID, location, inputID string) er
 	return nil
 }
 
-func createChannelIfNotExists(w io.Writer, projectID, location, channelID, requestJSONPath string) error {
+func createChannelIfNotExists(w io.Writer, projectID, location, channelID, requestJSONPath string, m *manifest.Manifest) error {
 	ctx := context.Background()
 	client, err := livestream.NewClient(ctx)
 	if err != nil {
@@ -125,6 +166,9 @@ func createChannelIfNotExists(w io.Writer, projectID, location, channelID, reque
 
 	if err == nil {
 		log.Printf("Channel %s already exists.\n", channelName)
+		if err := m.Record(manifest.KindChannel, channelName); err != nil {
+			log.Printf("Error updating manifest for channel %s: %v\n", channelName, err)
+		}
 		channelInfo := map[string]interface{}{
 			"channelID": channelName,
 			"inputID":   fmt.Sprintf("projects/%s/locations/%s/inputs/%s", projectID, location, inputID),
@@ -179,12 +223,18 @@ func createChannelIfNotExists(w io.Writer, projectID, location, channelID, reque
 		return fmt.Errorf("CreateChannel: %w", err)
 	}
 
-	response, err := op.Wait(ctx)
+	response, err := lroutil.Wait(ctx, w, op, func(cleanupCtx context.Context) error {
+		_, err := client.DeleteChannel(cleanupCtx, &livestreampb.DeleteChannelRequest{Name: channelName})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("Wait: %w", err)
 	}
 
 	log.Printf("Channel created: %s\n", response.Name)
+	if err := m.Record(manifest.KindChannel, response.Name); err != nil {
+		log.Printf("Error updating manifest for channel %s: %v\n", response.Name, err)
+	}
 
 	channelInfo := map[string]interface{}{
 		"channelID": response.Name,
@@ -214,6 +264,81 @@ func createChannelIfNotExists(w io.Writer, projectID, location, channelID, reque
 	return nil
 }
 
+// createMultiInputChannelIfNotExists creates a channel with one
+// InputAttachment per entry in inputIDs plus a trailing slate attachment,
+// mirroring createChannelIfNotExists but for multi-input workflows.
+func createMultiInputChannelIfNotExists(w io.Writer, projectID, location, channelID string, inputIDs []string, slateID, requestJSONPath string, m *manifest.Manifest) error {
+	ctx := context.Background()
+	client, err := livestream.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("NewClient: %w", err)
+	}
+	defer client.Close()
+
+	channelName := fmt.Sprintf("projects/%s/locations/%s/channels/%s", projectID, location, channelID)
+	reqGet := &livestreampb.GetChannelRequest{Name: channelName}
+	if _, err := client.GetChannel(ctx, reqGet); err == nil {
+		log.Printf("Channel %s already exists.\n", channelName)
+		if err := m.Record(manifest.KindChannel, channelName); err != nil {
+			log.Printf("Error updating manifest for channel %s: %v\n", channelName, err)
+		}
+		return nil
+	}
+
+	requestJSON, err := os.ReadFile(requestJSONPath)
+	if err != nil {
+		return fmt.Errorf("ReadFile: %w", err)
+	}
+	requestStr := strings.ReplaceAll(string(requestJSON), "<GCS_OUTPUT>", gcsoutput)
+
+	channel := &livestreampb.Channel{}
+	if err := protojson.Unmarshal([]byte(requestStr), channel); err != nil {
+		return fmt.Errorf("Unmarshal: %w", err)
+	}
+	channel.InputAttachments = switcher.BuildInputAttachments(projectID, location, inputIDs, slateID)
+
+	req := &livestreampb.CreateChannelRequest{
+		Parent:    fmt.Sprintf("projects/%s/locations/%s", projectID, location),
+		ChannelId: channelID,
+		Channel:   channel,
+	}
+
+	op, err := client.CreateChannel(ctx, req)
+	if err != nil {
+		return fmt.Errorf("CreateChannel: %w", err)
+	}
+	response, err := lroutil.Wait(ctx, w, op, func(cleanupCtx context.Context) error {
+		_, err := client.DeleteChannel(cleanupCtx, &livestreampb.DeleteChannelRequest{Name: channelName})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Wait: %w", err)
+	}
+	log.Printf("Multi-input channel created: %s\n", response.Name)
+	if err := m.Record(manifest.KindChannel, response.Name); err != nil {
+		log.Printf("Error updating manifest for channel %s: %v\n", response.Name, err)
+	}
+	return nil
+}
+
+// runSchedule parses the workflow at schedulePath and applies it to
+// channelName in order, blocking until every event has fired.
+func runSchedule(ctx context.Context, client *livestream.Client, channelName, schedulePath string) error {
+	f, err := os.Open(schedulePath)
+	if err != nil {
+		return fmt.Errorf("open schedule: %w", err)
+	}
+	defer f.Close()
+
+	events, err := switcher.ParseSchedule(f)
+	if err != nil {
+		return fmt.Errorf("parse schedule: %w", err)
+	}
+
+	runner := switcher.NewScheduleRunner(client, channelName, events)
+	return runner.Run(ctx)
+}
+
 func getChannelState(client *livestream.Client, channelName string) (livestreampb.Channel_StreamingState, error) {
 	ctx := context.Background()
 	req := &livestreampb.GetChannelRequest{
@@ -236,14 +361,46 @@ func printChannelState(client *livestream.Client, channelName string) {
 }
 
 func main() {
-	err := createInputIfNotExists(os.Stdout, projectID, location, inputID)
-	if err != nil {
-		log.Printf("Error during input creation: %v", err)
-	}
 	channelRequest := "request.json"
-	err = createChannelIfNotExists(os.Stdout, projectID, location, channelID, channelRequest)
+
+	m, err := manifest.Load(statePath, newRunID())
 	if err != nil {
-		log.Printf("Error creating channel: %v", err)
+		log.Printf("Error loading manifest %s: %v", statePath, err)
+		return
+	}
+
+	if useMultiInput {
+		for _, id := range append(append([]string{}, multiInputIDs...), slateInputID) {
+			if err := createInputIfNotExists(os.Stdout, projectID, location, id, m); err != nil {
+				log.Printf("Error during input creation for %s: %v", id, err)
+				if errors.Is(err, lroutil.ErrCanceled) {
+					log.Printf("Aborting: input creation was canceled by signal.")
+					return
+				}
+			}
+		}
+		if err := createMultiInputChannelIfNotExists(os.Stdout, projectID, location, channelID, multiInputIDs, slateInputID, channelRequest, m); err != nil {
+			log.Printf("Error creating multi-input channel: %v", err)
+			if errors.Is(err, lroutil.ErrCanceled) {
+				log.Printf("Aborting: channel creation was canceled by signal.")
+				return
+			}
+		}
+	} else {
+		if err := createInputIfNotExists(os.Stdout, projectID, location, inputID, m); err != nil {
+			log.Printf("Error during input creation: %v", err)
+			if errors.Is(err, lroutil.ErrCanceled) {
+				log.Printf("Aborting: input creation was canceled by signal.")
+				return
+			}
+		}
+		if err := createChannelIfNotExists(os.Stdout, projectID, location, channelID, channelRequest, m); err != nil {
+			log.Printf("Error creating channel: %v", err)
+			if errors.Is(err, lroutil.ErrCanceled) {
+				log.Printf("Aborting: channel creation was canceled by signal.")
+				return
+			}
+		}
 	}
 
 	ctx := context.Background()
@@ -275,7 +432,10 @@ func main() {
 			return
 		}
 
-		_, err = opStart.Wait(ctx)
+		_, err = lroutil.Wait(ctx, os.Stdout, opStart, func(cleanupCtx context.Context) error {
+			_, err := client.StopChannel(cleanupCtx, &livestreampb.StopChannelRequest{Name: channelName})
+			return err
+		})
 		if err != nil {
 			log.Printf("Error waiting for start operation: %v", err)
 			return
@@ -285,6 +445,12 @@ func main() {
 		log.Printf("Channel %s already started or starting, skipping start operation.\n", channelName)
 	}
 
+	if useMultiInput {
+		if err := runSchedule(ctx, client, channelName, schedulePath); err != nil {
+			log.Printf("Error running switch schedule: %v", err)
+		}
+	}
+
 	// Continuously display the channel's streaming state.
 	for {
 		currentState, err = getChannelState(client, channelName)
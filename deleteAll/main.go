@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,13 +11,18 @@ import (
 	livestream "cloud.google.com/go/video/livestream/apiv1"
 	"cloud.google.com/go/video/livestream/apiv1/livestreampb"
 	"google.golang.org/api/iterator"
+
+	"github.com/kesokaj/gcp-livestream-demo/lroutil"
+	"github.com/kesokaj/gcp-livestream-demo/manifest"
 )
 
 var projectID string = "<PROJECT_NUMBER>"
 var location string = "<REGION>"
+var statePath string = "state.json"
+var runID string = "default"
 
 // stopChannel stops a channel.
-func stopChannel(ctx context.Context, client *livestream.Client, channelName string) error {
+func stopChannel(ctx context.Context, client *livestream.Client, channelName string, w io.Writer) error {
 	stopReq := &livestreampb.StopChannelRequest{
 		Name: channelName,
 	}
@@ -24,8 +30,7 @@ func stopChannel(ctx context.Context, client *livestream.Client, channelName str
 	if err != nil {
 		return fmt.Errorf("StopChannel: %w", err)
 	}
-	// Corrected error handling for op.Wait()
-	_, err = op.Wait(ctx)
+	_, err = lroutil.Wait(ctx, w, op, nil)
 	if err != nil {
 		return fmt.Errorf("StopChannel Wait: %w", err)
 	}
@@ -90,86 +95,71 @@ func listEvents(ctx context.Context, client *livestream.Client, projectID, locat
 	return events, nil
 }
 
-func deleteAllInputs(w io.Writer, projectID, location string) error {
+// deleteAllInputs deletes only the inputs recorded in m, not every input in
+// the project/location, so this tool is safe to run against a shared
+// project.
+func deleteAllInputs(w io.Writer, client *livestream.Client, m *manifest.Manifest) error {
 	ctx := context.Background()
-	client, err := livestream.NewClient(ctx)
-	if err != nil {
-		return fmt.Errorf("NewClient: %w", err)
-	}
-	defer client.Close()
-
-	parent := fmt.Sprintf("projects/%s/locations/%s", projectID, location)
 
-	// List all inputs
-	inputIterator := client.ListInputs(ctx, &livestreampb.ListInputsRequest{
-		Parent: parent,
-	})
-
-	// Delete each input
-	for {
-		input, err := inputIterator.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("ListInputsIterator: %w", err)
-		}
-
-		// Delete the input
-		inputName := input.GetName()
+	for _, e := range m.Pending(manifest.KindInput) {
+		inputName := e.Name
 		log.Printf("Deleting input: %s\n", inputName)
-		_, err = client.DeleteInput(ctx, &livestreampb.DeleteInputRequest{
+		deleteOp, err := client.DeleteInput(ctx, &livestreampb.DeleteInputRequest{
 			Name: inputName,
 		})
 		if err != nil {
 			log.Printf("Error deleting input %s: %v\n", inputName, err) // Log and continue
-			// Don't return here, continue deleting other inputs
+			continue
+		}
+		if err := lroutil.WaitVoid(ctx, w, deleteOp, nil); err != nil {
+			if errors.Is(err, lroutil.ErrCanceled) {
+				return fmt.Errorf("deleting input %s: %w", inputName, err)
+			}
+			log.Printf("Error waiting for input %s to delete: %v\n", inputName, err) // Log and continue
+			continue
+		}
+		if err := m.MarkDone(inputName); err != nil {
+			log.Printf("Error updating manifest for input %s: %v\n", inputName, err)
 		}
 	}
 	return nil
 }
 
-func deleteAllChannels(w io.Writer, projectID, location string) error {
+// deleteAllChannels stops and deletes only the channels recorded in m, not
+// every channel in the project/location, so this tool is safe to run
+// against a shared project.
+func deleteAllChannels(w io.Writer, client *livestream.Client, m *manifest.Manifest) error {
 	ctx := context.Background()
-	client, err := livestream.NewClient(ctx)
-	if err != nil {
-		return fmt.Errorf("NewClient: %w", err)
-	}
-	defer client.Close()
-
-	parent := fmt.Sprintf("projects/%s/locations/%s", projectID, location)
 
-	// List all channels
-	channelIterator := client.ListChannels(ctx, &livestreampb.ListChannelsRequest{
-		Parent: parent,
-	})
-
-	// Stop and then Delete each channel
-	for {
-		channel, err := channelIterator.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("ListChannelsIterator: %w", err)
-		}
-
-		channelName := channel.GetName()
+	for _, e := range m.Pending(manifest.KindChannel) {
+		channelName := e.Name
 		// Stop the channel first.
-		err = stopChannel(ctx, client, channelName)
-		if err != nil {
+		if err := stopChannel(ctx, client, channelName, w); err != nil {
+			if errors.Is(err, lroutil.ErrCanceled) {
+				return fmt.Errorf("stopping channel %s: %w", channelName, err)
+			}
 			log.Printf("Error stopping channel %s: %v\n", channelName, err)
 			// keep going and try to delete other channels
 		}
 
 		// Delete the channel
 		log.Printf("Deleting channel: %s\n", channelName)
-		_, err = client.DeleteChannel(ctx, &livestreampb.DeleteChannelRequest{
+		deleteOp, err := client.DeleteChannel(ctx, &livestreampb.DeleteChannelRequest{
 			Name: channelName,
 		})
 		if err != nil {
 			log.Printf("Error deleting channel %s: %v\n", channelName, err) // Log and continue
-			// Don't return here, continue deleting other channels
+			continue
+		}
+		if err := lroutil.WaitVoid(ctx, w, deleteOp, nil); err != nil {
+			if errors.Is(err, lroutil.ErrCanceled) {
+				return fmt.Errorf("deleting channel %s: %w", channelName, err)
+			}
+			log.Printf("Error waiting for channel %s to delete: %v\n", channelName, err) // Log and continue
+			continue
+		}
+		if err := m.MarkDone(channelName); err != nil {
+			log.Printf("Error updating manifest for channel %s: %v\n", channelName, err)
 		}
 	}
 	return nil
@@ -219,16 +209,37 @@ func main() {
 
 	}
 
-	// Delete all channels first
-	err = deleteAllChannels(os.Stdout, projectID, location)
+	m, err := manifest.Load(statePath, runID)
+	if err != nil {
+		log.Printf("Error loading manifest %s: %v", statePath, err)
+		return
+	}
+
+	// Delete only the channels this tool created, per the manifest.
+	err = deleteAllChannels(os.Stdout, client, m)
 	if err != nil {
 		log.Printf("Error deleting channels: %v", err)
+		if errors.Is(err, lroutil.ErrCanceled) {
+			log.Printf("Aborting: teardown was canceled by signal. Re-run to pick up where it left off.")
+			return
+		}
 	}
 
-	// Delete all inputs
-	err = deleteAllInputs(os.Stdout, projectID, location)
+	// Delete only the inputs this tool created, per the manifest.
+	err = deleteAllInputs(os.Stdout, client, m)
 	if err != nil {
 		log.Printf("Error deleting inputs: %v", err)
+		if errors.Is(err, lroutil.ErrCanceled) {
+			log.Printf("Aborting: teardown was canceled by signal. Re-run to pick up where it left off.")
+			return
+		}
 	}
+
+	// Pick up anything left dangling (e.g. this process was interrupted
+	// mid-teardown on a previous run) with retried, backed-off calls.
+	if err := manifest.Reconcile(ctx, client, m); err != nil {
+		log.Printf("Error reconciling manifest: %v", err)
+	}
+
 	log.Println("Finished deleting all channels and inputs.")
 }
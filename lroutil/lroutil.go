@@ -0,0 +1,143 @@
+// Package lroutil wraps long-running Live Stream API operations with a
+// progress reporter and graceful SIGINT/SIGTERM cancellation, so operators
+// running the createStream/deleteAll tools interactively get feedback
+// instead of staring at a blocked op.Wait(ctx) for minutes.
+package lroutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"cloud.google.com/go/video/livestream/apiv1/livestreampb"
+	gax "github.com/googleapis/gax-go/v2"
+)
+
+// pollInterval is how often the progress line is refreshed.
+const pollInterval = 2 * time.Second
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// ErrCanceled is returned by Wait when a SIGINT/SIGTERM aborted the
+// operation. By the time it's returned, onCancel has already run to best
+// effort clean up the partially created/deleted resource. Callers that
+// issue a series of operations (e.g. one per input, or one per channel to
+// tear down) should check errors.Is(err, ErrCanceled) and stop the series
+// instead of moving on to the next resource.
+var ErrCanceled = errors.New("lroutil: operation canceled by signal")
+
+// Operation is the subset of the generated *Operation types (e.g.
+// *livestream.CreateInputOperation, *livestream.StopChannelOperation) that
+// Wait needs: the ability to block for the result, report its name, and
+// report intermediate metadata.
+type Operation[T any] interface {
+	Wait(ctx context.Context, opts ...gax.CallOption) (T, error)
+	Metadata() (*livestreampb.OperationMetadata, error)
+	Name() string
+}
+
+// VoidOperation is the subset of the generated *Operation types whose Wait
+// has no typed response (e.g. *livestream.DeleteChannelOperation,
+// *livestream.DeleteInputOperation return only an error, since the
+// underlying RPC response is google.protobuf.Empty).
+type VoidOperation interface {
+	Wait(ctx context.Context, opts ...gax.CallOption) error
+	Metadata() (*livestreampb.OperationMetadata, error)
+	Name() string
+}
+
+// voidOperation adapts a VoidOperation to Operation[struct{}] so WaitVoid can
+// share Wait's progress reporting and cancellation handling.
+type voidOperation struct {
+	op VoidOperation
+}
+
+func (v voidOperation) Wait(ctx context.Context, opts ...gax.CallOption) (struct{}, error) {
+	return struct{}{}, v.op.Wait(ctx, opts...)
+}
+
+func (v voidOperation) Metadata() (*livestreampb.OperationMetadata, error) {
+	return v.op.Metadata()
+}
+
+func (v voidOperation) Name() string { return v.op.Name() }
+
+// WaitVoid is Wait for operations whose Wait returns no typed response.
+func WaitVoid(ctx context.Context, w io.Writer, op VoidOperation, onCancel func(context.Context) error) error {
+	_, err := Wait(ctx, w, voidOperation{op}, onCancel)
+	return err
+}
+
+// Wait blocks until op completes, printing a periodic progress line to w
+// (elapsed time, spinner, operation name, and any metadata target
+// available). If ctx is canceled via SIGINT/SIGTERM, Wait calls onCancel
+// (if non-nil) for best-effort cleanup of a partially created resource,
+// then returns a cancellation error once op.Wait unblocks.
+func Wait[T any](ctx context.Context, w io.Writer, op Operation[T], onCancel func(context.Context) error) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	type result struct {
+		resp T
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := op.Wait(ctx)
+		done <- result{resp, err}
+	}()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	frame := 0
+	canceling := false
+
+	for {
+		select {
+		case r := <-done:
+			fmt.Fprintf(w, "\n")
+			if canceling {
+				if r.err != nil {
+					return r.resp, fmt.Errorf("%s: %w (%v)", op.Name(), ErrCanceled, r.err)
+				}
+				return r.resp, fmt.Errorf("%s: %w", op.Name(), ErrCanceled)
+			}
+			return r.resp, r.err
+
+		case sig := <-sigCh:
+			if canceling {
+				continue
+			}
+			canceling = true
+			fmt.Fprintf(w, "\nreceived %s, canceling %s...\n", sig, op.Name())
+			cancel()
+			if onCancel != nil {
+				cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+				if err := onCancel(cleanupCtx); err != nil {
+					fmt.Fprintf(w, "cleanup after cancel failed: %v\n", err)
+				}
+				cleanupCancel()
+			}
+
+		case <-ticker.C:
+			elapsed := time.Since(start).Round(time.Second)
+			status := ""
+			if meta, err := op.Metadata(); err == nil && meta != nil {
+				status = fmt.Sprintf(" target=%s verb=%s", meta.GetTarget(), meta.GetVerb())
+			}
+			fmt.Fprintf(w, "\r[%s] %s elapsed=%s%s", spinnerFrames[frame%len(spinnerFrames)], op.Name(), elapsed, status)
+			frame++
+		}
+	}
+}
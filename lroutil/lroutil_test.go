@@ -0,0 +1,91 @@
+package lroutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/video/livestream/apiv1/livestreampb"
+	gax "github.com/googleapis/gax-go/v2"
+)
+
+// fakeOp is a minimal Operation[string] whose Wait either returns once
+// done is closed or, like a real operation, gives up as soon as ctx is
+// canceled.
+type fakeOp struct {
+	name string
+	done chan struct{}
+}
+
+func (f *fakeOp) Wait(ctx context.Context, opts ...gax.CallOption) (string, error) {
+	select {
+	case <-f.done:
+		return "ok", nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (f *fakeOp) Metadata() (*livestreampb.OperationMetadata, error) {
+	return nil, nil
+}
+
+func (f *fakeOp) Name() string { return f.name }
+
+func TestWaitReturnsResultOnCompletion(t *testing.T) {
+	op := &fakeOp{name: "test-op", done: make(chan struct{})}
+	close(op.done)
+
+	resp, err := Wait(context.Background(), io.Discard, op, nil)
+	if err != nil {
+		t.Fatalf("Wait() returned error: %v", err)
+	}
+	if resp != "ok" {
+		t.Fatalf("Wait() resp = %q, want %q", resp, "ok")
+	}
+}
+
+func TestWaitPropagatesCancellation(t *testing.T) {
+	op := &fakeOp{name: "test-op", done: make(chan struct{})}
+
+	var mu sync.Mutex
+	var cleanupCalled bool
+	onCancel := func(ctx context.Context) error {
+		mu.Lock()
+		cleanupCalled = true
+		mu.Unlock()
+		return nil
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := Wait(context.Background(), io.Discard, op, onCancel)
+		result <- err
+	}()
+
+	// Give Wait time to register its signal handler before we send one.
+	time.Sleep(100 * time.Millisecond)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatalf("sending SIGINT: %v", err)
+	}
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrCanceled) {
+			t.Fatalf("Wait() err = %v, want it to wrap ErrCanceled", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Wait() did not return after SIGINT")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !cleanupCalled {
+		t.Fatal("onCancel was not called")
+	}
+}
@@ -0,0 +1,258 @@
+// Package manifest records the resources a run of this tool creates into a
+// local state file, keyed by a run ID, so teardown only touches resources
+// this tool is actually responsible for instead of blindly nuking everything
+// in a project/location. createStream records the inputs and channels it
+// creates; the events it creates through the switcher package are not
+// recorded, and Reconcile tears down channels and inputs only.
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	livestream "cloud.google.com/go/video/livestream/apiv1"
+	"cloud.google.com/go/video/livestream/apiv1/livestreampb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Kind identifies the type of GCP resource an Entry tracks.
+type Kind string
+
+const (
+	KindInput   Kind = "input"
+	KindChannel Kind = "channel"
+	KindEvent   Kind = "event"
+)
+
+// Status is the lifecycle state of a tracked Entry.
+type Status string
+
+const (
+	// StatusCreated means the resource was created and is still expected
+	// to exist.
+	StatusCreated Status = "created"
+	// StatusDone means the resource has been torn down.
+	StatusDone Status = "done"
+)
+
+// Entry is one resource this tool created, identified by its full
+// resource name (e.g. "projects/.../locations/.../channels/...").
+type Entry struct {
+	Kind      Kind      `json:"kind"`
+	Name      string    `json:"name"`
+	Status    Status    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Manifest is the on-disk record of everything a run created. It is safe
+// for concurrent use.
+type Manifest struct {
+	RunID   string   `json:"run_id"`
+	Entries []*Entry `json:"entries"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// New creates an empty Manifest for runID that will persist to path.
+func New(runID, path string) *Manifest {
+	return &Manifest{RunID: runID, path: path}
+}
+
+// Load reads a Manifest previously saved at path. If path does not exist,
+// Load returns a new empty Manifest for runID rather than an error, so a
+// first run doesn't need special-casing.
+func Load(path, runID string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return New(runID, path), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest %s: %w", path, err)
+	}
+
+	m := &Manifest{path: path}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("unmarshal manifest %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Record adds an Entry for a newly created resource and persists the
+// manifest to disk.
+func (m *Manifest) Record(kind Kind, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Entries = append(m.Entries, &Entry{
+		Kind:      kind,
+		Name:      name,
+		Status:    StatusCreated,
+		CreatedAt: time.Now(),
+	})
+	return m.save()
+}
+
+// MarkDone marks the Entry for name as torn down and persists the
+// manifest to disk.
+func (m *Manifest) MarkDone(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.Entries {
+		if e.Name == name {
+			e.Status = StatusDone
+		}
+	}
+	return m.save()
+}
+
+// Pending returns the not-yet-torn-down entries of the given kind, in the
+// order they were recorded.
+func (m *Manifest) Pending(kind Kind) []*Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []*Entry
+	for _, e := range m.Entries {
+		if e.Kind == kind && e.Status != StatusDone {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Save persists the manifest to its configured path.
+func (m *Manifest) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.save()
+}
+
+// save writes the manifest to disk. Callers must hold m.mu.
+func (m *Manifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(m.path, data, 0o644); err != nil {
+		return fmt.Errorf("write manifest %s: %w", m.path, err)
+	}
+	return nil
+}
+
+// backoffSchedule is the delay before each retry attempt when reconciling
+// a single entry against the live API.
+var backoffSchedule = []time.Duration{
+	1 * time.Second,
+	2 * time.Second,
+	4 * time.Second,
+	8 * time.Second,
+	16 * time.Second,
+}
+
+// Reconcile diffs the manifest against the live API and re-issues the
+// missing StopChannel/DeleteChannel/DeleteInput calls (with exponential
+// backoff) for every channel and input entry still marked as created
+// (KindEvent entries are left as they are), marking each Done as
+// it's confirmed torn down. It makes deleteAllChannels/deleteAllInputs
+// callers re-runnable: a run that was interrupted partway through teardown
+// can call Reconcile again and pick up where it left off.
+func Reconcile(ctx context.Context, client *livestream.Client, m *Manifest) error {
+	for _, e := range m.Pending(KindChannel) {
+		if err := reconcileChannel(ctx, client, e); err != nil {
+			log.Printf("manifest: reconcile channel %s: %v", e.Name, err)
+			continue
+		}
+		if err := m.MarkDone(e.Name); err != nil {
+			return fmt.Errorf("mark channel %s done: %w", e.Name, err)
+		}
+	}
+
+	for _, e := range m.Pending(KindInput) {
+		if err := reconcileInput(ctx, client, e); err != nil {
+			log.Printf("manifest: reconcile input %s: %v", e.Name, err)
+			continue
+		}
+		if err := m.MarkDone(e.Name); err != nil {
+			return fmt.Errorf("mark input %s done: %w", e.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func reconcileChannel(ctx context.Context, client *livestream.Client, e *Entry) error {
+	return withBackoff(ctx, func(ctx context.Context) error {
+		_, err := client.GetChannel(ctx, &livestreampb.GetChannelRequest{Name: e.Name})
+		if status.Code(err) == codes.NotFound {
+			// Already gone; nothing left to reconcile.
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("GetChannel: %w", err)
+		}
+
+		if stopOp, err := client.StopChannel(ctx, &livestreampb.StopChannelRequest{Name: e.Name}); err == nil {
+			if _, err := stopOp.Wait(ctx); err != nil {
+				log.Printf("manifest: StopChannel Wait %s: %v", e.Name, err)
+			}
+		}
+
+		deleteOp, err := client.DeleteChannel(ctx, &livestreampb.DeleteChannelRequest{Name: e.Name})
+		if err != nil {
+			return fmt.Errorf("DeleteChannel: %w", err)
+		}
+		if err := deleteOp.Wait(ctx); err != nil {
+			return fmt.Errorf("DeleteChannel Wait: %w", err)
+		}
+		return nil
+	})
+}
+
+func reconcileInput(ctx context.Context, client *livestream.Client, e *Entry) error {
+	return withBackoff(ctx, func(ctx context.Context) error {
+		_, err := client.GetInput(ctx, &livestreampb.GetInputRequest{Name: e.Name})
+		if status.Code(err) == codes.NotFound {
+			// Already gone; nothing left to reconcile.
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("GetInput: %w", err)
+		}
+
+		deleteOp, err := client.DeleteInput(ctx, &livestreampb.DeleteInputRequest{Name: e.Name})
+		if err != nil {
+			return fmt.Errorf("DeleteInput: %w", err)
+		}
+		if err := deleteOp.Wait(ctx); err != nil {
+			return fmt.Errorf("DeleteInput Wait: %w", err)
+		}
+		return nil
+	})
+}
+
+// withBackoff retries fn according to backoffSchedule, returning the last
+// error if every attempt fails.
+func withBackoff(ctx context.Context, fn func(context.Context) error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if attempt >= len(backoffSchedule) {
+			return err
+		}
+		select {
+		case <-time.After(backoffSchedule[attempt]):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package manifest
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordMarkDoneAndPending(t *testing.T) {
+	m := New("run-1", filepath.Join(t.TempDir(), "state.json"))
+
+	if err := m.Record(KindInput, "input-01"); err != nil {
+		t.Fatalf("Record(input-01): %v", err)
+	}
+	if err := m.Record(KindInput, "input-02"); err != nil {
+		t.Fatalf("Record(input-02): %v", err)
+	}
+	if err := m.Record(KindChannel, "channel-01"); err != nil {
+		t.Fatalf("Record(channel-01): %v", err)
+	}
+
+	pendingInputs := m.Pending(KindInput)
+	if len(pendingInputs) != 2 {
+		t.Fatalf("Pending(KindInput) returned %d entries, want 2", len(pendingInputs))
+	}
+	if pendingInputs[0].Name != "input-01" || pendingInputs[1].Name != "input-02" {
+		t.Fatalf("Pending(KindInput) = %+v, want input-01 then input-02 in order", pendingInputs)
+	}
+
+	if err := m.MarkDone("input-01"); err != nil {
+		t.Fatalf("MarkDone(input-01): %v", err)
+	}
+
+	pendingInputs = m.Pending(KindInput)
+	if len(pendingInputs) != 1 || pendingInputs[0].Name != "input-02" {
+		t.Fatalf("Pending(KindInput) after MarkDone = %+v, want only input-02", pendingInputs)
+	}
+
+	pendingChannels := m.Pending(KindChannel)
+	if len(pendingChannels) != 1 || pendingChannels[0].Name != "channel-01" {
+		t.Fatalf("Pending(KindChannel) = %+v, want only channel-01", pendingChannels)
+	}
+}
+
+func TestLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	m := New("run-1", path)
+	if err := m.Record(KindInput, "input-01"); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := m.MarkDone("input-01"); err != nil {
+		t.Fatalf("MarkDone: %v", err)
+	}
+
+	loaded, err := Load(path, "run-1")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.RunID != "run-1" {
+		t.Fatalf("loaded.RunID = %q, want %q", loaded.RunID, "run-1")
+	}
+	if len(loaded.Pending(KindInput)) != 0 {
+		t.Fatalf("loaded manifest has pending inputs, want none: %+v", loaded.Pending(KindInput))
+	}
+}
+
+func TestLoadMissingFileReturnsEmptyManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "does-not-exist.json")
+
+	m, err := Load(path, "run-2")
+	if err != nil {
+		t.Fatalf("Load on missing file: %v", err)
+	}
+	if m.RunID != "run-2" {
+		t.Fatalf("m.RunID = %q, want %q", m.RunID, "run-2")
+	}
+	if len(m.Entries) != 0 {
+		t.Fatalf("m.Entries = %+v, want empty", m.Entries)
+	}
+}
+
+func TestWithBackoffSucceedsAfterRetries(t *testing.T) {
+	orig := backoffSchedule
+	backoffSchedule = []time.Duration{time.Millisecond, time.Millisecond}
+	defer func() { backoffSchedule = orig }()
+
+	attempts := 0
+	err := withBackoff(context.Background(), func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withBackoff: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestWithBackoffReturnsLastErrorAfterExhausted(t *testing.T) {
+	orig := backoffSchedule
+	backoffSchedule = []time.Duration{time.Millisecond}
+	defer func() { backoffSchedule = orig }()
+
+	wantErr := errors.New("still failing")
+	attempts := 0
+	err := withBackoff(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("withBackoff err = %v, want %v", err, wantErr)
+	}
+	if attempts != 2 {
+		t.Fatalf("attempts = %d, want 2 (1 initial + 1 retry)", attempts)
+	}
+}
+
+func TestWithBackoffStopsOnContextCancel(t *testing.T) {
+	orig := backoffSchedule
+	backoffSchedule = []time.Duration{time.Hour}
+	defer func() { backoffSchedule = orig }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts := 0
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- withBackoff(ctx, func(ctx context.Context) error {
+			attempts++
+			return errors.New("transient")
+		})
+	}()
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("withBackoff err = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("withBackoff did not return after context cancellation")
+	}
+}
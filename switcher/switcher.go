@@ -0,0 +1,285 @@
+// Package switcher builds multi-input channels and drives scheduled or
+// health-triggered input-switching events against them.
+package switcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	livestream "cloud.google.com/go/video/livestream/apiv1"
+	"cloud.google.com/go/video/livestream/apiv1/livestreampb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// SlateInputKey is the InputAttachment key reserved for the channel's slate,
+// used by both scheduled switches and automatic failover.
+const SlateInputKey = "slate"
+
+// SwitchEvent is one entry in an input-switching workflow: at time At
+// (relative to channel start), switch the output to Input, which is either
+// an input attachment key or SlateInputKey. When Input is SlateInputKey,
+// SlateAsset (a slate Asset resource name) is required and SlateDuration is
+// optional (zero means a long-running slate, per the API).
+type SwitchEvent struct {
+	At            time.Duration `json:"at"`
+	Input         string        `json:"input"`
+	SlateAsset    string        `json:"slate_asset,omitempty"`
+	SlateDuration time.Duration `json:"slate_duration,omitempty"`
+}
+
+// ParseSchedule reads a JSON workflow of the form
+// [{"at": "0s", "input": "01"},
+//
+//	{"at": "30s", "input": "slate", "slate_asset": "projects/p/locations/l/assets/a"}]
+//
+// and returns it as an ordered list of SwitchEvents.
+func ParseSchedule(r io.Reader) ([]SwitchEvent, error) {
+	var raw []struct {
+		At            string `json:"at"`
+		Input         string `json:"input"`
+		SlateAsset    string `json:"slate_asset"`
+		SlateDuration string `json:"slate_duration"`
+	}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode schedule: %w", err)
+	}
+
+	events := make([]SwitchEvent, 0, len(raw))
+	for _, entry := range raw {
+		at, err := time.ParseDuration(entry.At)
+		if err != nil {
+			return nil, fmt.Errorf("parse at %q: %w", entry.At, err)
+		}
+
+		event := SwitchEvent{At: at, Input: entry.Input, SlateAsset: entry.SlateAsset}
+		if entry.SlateDuration != "" {
+			d, err := time.ParseDuration(entry.SlateDuration)
+			if err != nil {
+				return nil, fmt.Errorf("parse slate_duration %q: %w", entry.SlateDuration, err)
+			}
+			event.SlateDuration = d
+		}
+		if event.Input == SlateInputKey && event.SlateAsset == "" {
+			return nil, fmt.Errorf("event at %s switches to slate but has no slate_asset", entry.At)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// BuildInputAttachments returns one InputAttachment per inputID plus a
+// trailing slate attachment, suitable for Channel.InputAttachments.
+func BuildInputAttachments(projectID, location string, inputIDs []string, slateID string) []*livestreampb.InputAttachment {
+	attachments := make([]*livestreampb.InputAttachment, 0, len(inputIDs)+1)
+	for _, id := range inputIDs {
+		attachments = append(attachments, &livestreampb.InputAttachment{
+			Key:   id,
+			Input: fmt.Sprintf("projects/%s/locations/%s/inputs/%s", projectID, location, id),
+		})
+	}
+	if slateID != "" {
+		attachments = append(attachments, &livestreampb.InputAttachment{
+			Key:   SlateInputKey,
+			Input: fmt.Sprintf("projects/%s/locations/%s/inputs/%s", projectID, location, slateID),
+		})
+	}
+	return attachments
+}
+
+// ScheduleRunner tracks execution state while a schedule of SwitchEvents is
+// applied to a running channel.
+type ScheduleRunner struct {
+	client      *livestream.Client
+	channelName string
+	events      []SwitchEvent
+	executed    []bool
+}
+
+// NewScheduleRunner returns a ScheduleRunner for channelName, ready to Run
+// the given schedule in order.
+func NewScheduleRunner(client *livestream.Client, channelName string, events []SwitchEvent) *ScheduleRunner {
+	return &ScheduleRunner{
+		client:      client,
+		channelName: channelName,
+		events:      events,
+		executed:    make([]bool, len(events)),
+	}
+}
+
+// Run blocks until every event in the schedule has fired (in order,
+// relative to the moment Run is called) or ctx is canceled.
+func (r *ScheduleRunner) Run(ctx context.Context) error {
+	start := time.Now()
+	for i, event := range r.events {
+		wait := event.At - time.Since(start)
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if err := r.fire(ctx, event); err != nil {
+			return fmt.Errorf("switch event %d (%s at %s): %w", i, event.Input, event.At, err)
+		}
+		r.executed[i] = true
+		log.Printf("switcher: switched %s to %s at %s", r.channelName, event.Input, event.At)
+	}
+	return nil
+}
+
+// Executed reports whether the i-th event in the schedule has fired.
+func (r *ScheduleRunner) Executed(i int) bool {
+	return r.executed[i]
+}
+
+func (r *ScheduleRunner) fire(ctx context.Context, event SwitchEvent) error {
+	e := &livestreampb.Event{
+		ExecuteNow: true,
+	}
+	if event.Input == SlateInputKey {
+		slateTask := &livestreampb.Event_SlateTask{Asset: event.SlateAsset}
+		if event.SlateDuration > 0 {
+			slateTask.Duration = durationpb.New(event.SlateDuration)
+		}
+		e.Task = &livestreampb.Event_Slate{Slate: slateTask}
+	} else {
+		e.Task = &livestreampb.Event_InputSwitch{
+			InputSwitch: &livestreampb.Event_InputSwitchTask{
+				InputKey: event.Input,
+			},
+		}
+	}
+
+	req := &livestreampb.CreateEventRequest{
+		Parent:  r.channelName,
+		EventId: fmt.Sprintf("switch-%s-%d", event.Input, event.At.Milliseconds()),
+		Event:   e,
+	}
+	_, err := r.client.CreateEvent(ctx, req)
+	if err != nil {
+		return fmt.Errorf("CreateEvent: %w", err)
+	}
+	return nil
+}
+
+// MergerOptions configures a Merger control loop.
+type MergerOptions struct {
+	// PrimaryInputKey is the InputAttachment key of the feed to prefer.
+	PrimaryInputKey string
+	// PrimaryInputName is the full input resource name to poll for health.
+	PrimaryInputName string
+	// GracePeriod is how long the primary input may stay NOT_CONNECTED
+	// before the Merger falls back to slate.
+	GracePeriod time.Duration
+	// PollInterval is how often to check input health. Defaults to 5s.
+	PollInterval time.Duration
+	// SlateAsset is the slate Asset resource name to play during failover,
+	// e.g. "projects/p/locations/l/assets/a".
+	SlateAsset string
+	// SlateDuration bounds how long the slate plays once triggered. Zero
+	// means a long-running slate, left in place until switched back.
+	SlateDuration time.Duration
+}
+
+// Merger watches a primary input's health and falls back the channel output
+// to slate when it has been disconnected for longer than GracePeriod,
+// switching back once it recovers.
+type Merger struct {
+	client      *livestream.Client
+	channelName string
+	opts        MergerOptions
+}
+
+// NewMerger returns a Merger that will watch opts.PrimaryInputName and drive
+// input-switch events against channelName.
+func NewMerger(client *livestream.Client, channelName string, opts MergerOptions) *Merger {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+	return &Merger{client: client, channelName: channelName, opts: opts}
+}
+
+// Watch runs the health-check control loop until ctx is canceled, injecting
+// a switch-to-slate event when the primary input drops for longer than
+// GracePeriod, and a switch-back event once it reconnects.
+func (m *Merger) Watch(ctx context.Context) error {
+	ticker := time.NewTicker(m.opts.PollInterval)
+	defer ticker.Stop()
+
+	var disconnectedSince time.Time
+	onSlate := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		req := &livestreampb.GetInputRequest{Name: m.opts.PrimaryInputName}
+		input, err := m.client.GetInput(ctx, req)
+		if err != nil {
+			log.Printf("switcher: GetInput %s: %v", m.opts.PrimaryInputName, err)
+			continue
+		}
+
+		connected := input.GetInputStreamProperty() != nil
+
+		if !connected {
+			if disconnectedSince.IsZero() {
+				disconnectedSince = time.Now()
+			}
+			if !onSlate && time.Since(disconnectedSince) > m.opts.GracePeriod {
+				if err := m.switchTo(ctx, SlateInputKey); err != nil {
+					log.Printf("switcher: failover to slate: %v", err)
+					continue
+				}
+				onSlate = true
+				log.Printf("switcher: %s disconnected for >%s, failed over to slate", m.opts.PrimaryInputKey, m.opts.GracePeriod)
+			}
+			continue
+		}
+
+		disconnectedSince = time.Time{}
+		if onSlate {
+			if err := m.switchTo(ctx, m.opts.PrimaryInputKey); err != nil {
+				log.Printf("switcher: switch back to %s: %v", m.opts.PrimaryInputKey, err)
+				continue
+			}
+			onSlate = false
+			log.Printf("switcher: %s reconnected, switched back", m.opts.PrimaryInputKey)
+		}
+	}
+}
+
+func (m *Merger) switchTo(ctx context.Context, inputKey string) error {
+	e := &livestreampb.Event{ExecuteNow: true}
+	if inputKey == SlateInputKey {
+		slateTask := &livestreampb.Event_SlateTask{Asset: m.opts.SlateAsset}
+		if m.opts.SlateDuration > 0 {
+			slateTask.Duration = durationpb.New(m.opts.SlateDuration)
+		}
+		e.Task = &livestreampb.Event_Slate{Slate: slateTask}
+	} else {
+		e.Task = &livestreampb.Event_InputSwitch{
+			InputSwitch: &livestreampb.Event_InputSwitchTask{InputKey: inputKey},
+		}
+	}
+
+	req := &livestreampb.CreateEventRequest{
+		Parent:  m.channelName,
+		EventId: fmt.Sprintf("failover-%s-%d", inputKey, time.Now().UnixNano()),
+		Event:   e,
+	}
+	_, err := m.client.CreateEvent(ctx, req)
+	if err != nil {
+		return fmt.Errorf("CreateEvent: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package switcher
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/video/livestream/apiv1/livestreampb"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    []SwitchEvent
+		wantErr bool
+	}{
+		{
+			name: "ordered events",
+			json: `[{"at": "0s", "input": "01"}, {"at": "30s", "input": "slate", "slate_asset": "projects/p/locations/l/assets/a", "slate_duration": "5s"}]`,
+			want: []SwitchEvent{
+				{At: 0, Input: "01"},
+				{At: 30 * time.Second, Input: "slate", SlateAsset: "projects/p/locations/l/assets/a", SlateDuration: 5 * time.Second},
+			},
+		},
+		{
+			name: "empty schedule",
+			json: `[]`,
+			want: []SwitchEvent{},
+		},
+		{
+			name:    "invalid duration",
+			json:    `[{"at": "soon", "input": "01"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "slate without slate_asset",
+			json:    `[{"at": "0s", "input": "slate"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed json",
+			json:    `not json`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSchedule(strings.NewReader(tt.json))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSchedule(%q) = %v, nil, want error", tt.json, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) returned error: %v", tt.json, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSchedule(%q) = %+v, want %+v", tt.json, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildInputAttachments(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputIDs []string
+		slateID  string
+		want     []*livestreampb.InputAttachment
+	}{
+		{
+			name:     "inputs plus slate",
+			inputIDs: []string{"01", "02"},
+			slateID:  "slate-input",
+			want: []*livestreampb.InputAttachment{
+				{Key: "01", Input: "projects/proj/locations/us-central1/inputs/01"},
+				{Key: "02", Input: "projects/proj/locations/us-central1/inputs/02"},
+				{Key: SlateInputKey, Input: "projects/proj/locations/us-central1/inputs/slate-input"},
+			},
+		},
+		{
+			name:     "no slate",
+			inputIDs: []string{"01"},
+			slateID:  "",
+			want: []*livestreampb.InputAttachment{
+				{Key: "01", Input: "projects/proj/locations/us-central1/inputs/01"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildInputAttachments("proj", "us-central1", tt.inputIDs, tt.slateID)
+			if len(got) != len(tt.want) {
+				t.Fatalf("BuildInputAttachments() returned %d attachments, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].Key != tt.want[i].Key || got[i].Input != tt.want[i].Input {
+					t.Errorf("attachment %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Command tester runs a Streamer2 smoke test against an RTMP input and its
+// HLS output from the command line, e.g. right after createStream reports a
+// channel as STREAMING.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/kesokaj/gcp-livestream-demo/tester"
+)
+
+func main() {
+	source := flag.String("source", "", "local media file (mp4/flv/ts) to push")
+	rtmpURL := flag.String("rtmp_url", "", "RTMP push URI returned by CreateInput")
+	hlsBase := flag.String("hls_base", "", "HTTP(S) base URL of the channel's HLS output")
+	duration := flag.Duration("duration", 60*time.Second, "how long to stream the source")
+	waitForTarget := flag.Duration("wait_for_target", 30*time.Second, "how much longer than -duration to keep watching the HLS output")
+	flag.Parse()
+
+	streamer, err := tester.NewStreamer2(context.Background(), tester.Streamer2Options{
+		Source:        *source,
+		RTMPURL:       *rtmpURL,
+		HLSBase:       *hlsBase,
+		Duration:      *duration,
+		WaitForTarget: *waitForTarget,
+		Writer:        os.Stdout,
+	})
+	if err != nil {
+		log.Fatalf("NewStreamer2: %v", err)
+	}
+
+	summary, err := streamer.Run()
+	if err != nil {
+		log.Fatalf("Run: %v", err)
+	}
+
+	log.Printf("first manifest latency: %s", summary.FirstManifestLatency)
+	log.Printf("first segment latency: %s", summary.FirstSegmentLatency)
+	log.Printf("segments observed: %d", len(summary.SegmentsObserved))
+	log.Printf("bytes pushed: %d", summary.BytesPushed)
+	if summary.FFmpegErr != nil {
+		log.Printf("ffmpeg error: %v", summary.FFmpegErr)
+	}
+}
@@ -0,0 +1,323 @@
+// Package tester pushes a local media file to a Live Stream API RTMP input
+// and validates that the corresponding HLS output shows up in GCS, so a
+// freshly created channel can be smoke-tested both interactively and in CI.
+package tester
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Streamer2Options configures a single ingest-to-playout smoke test.
+type Streamer2Options struct {
+	// Source is the local media file (mp4/flv/ts) to stream at real-time rate.
+	Source string
+	// RTMPURL is the input's RTMP push URI, as returned by CreateInput.
+	RTMPURL string
+	// HLSBase is the HTTP(S) base URL of the channel's HLS output, e.g.
+	// https://storage.googleapis.com/<bucket>/<input-id>/.
+	HLSBase string
+	// Duration is how long to stream the source before stopping.
+	Duration time.Duration
+	// WaitForTarget is how much longer than Duration Run keeps polling the
+	// HLS output, so Run's overall deadline is Duration+WaitForTarget. Run
+	// does not fail when no manifest or segment appears by then; the
+	// corresponding Summary latencies stay zero.
+	WaitForTarget time.Duration
+	// Writer receives the per-second stats lines. Defaults to io.Discard.
+	Writer io.Writer
+}
+
+// SegmentObservation records when a single HLS media segment was first seen.
+type SegmentObservation struct {
+	Name       string
+	ObservedAt time.Time
+}
+
+// Summary is the result of a completed Run.
+type Summary struct {
+	BytesPushed          int64
+	SegmentsObserved     []SegmentObservation
+	FirstSegmentLatency  time.Duration
+	FirstManifestLatency time.Duration
+	Gaps                 []time.Duration
+	FFmpegErr            error
+}
+
+// Streamer2 drives one ingest/playout smoke test run.
+type Streamer2 struct {
+	opts Streamer2Options
+	ctx  context.Context
+}
+
+// NewStreamer2 validates opts and returns a Streamer2 ready to Run.
+func NewStreamer2(ctx context.Context, opts Streamer2Options) (*Streamer2, error) {
+	if opts.Source == "" {
+		return nil, fmt.Errorf("Streamer2Options.Source is required")
+	}
+	if opts.RTMPURL == "" {
+		return nil, fmt.Errorf("Streamer2Options.RTMPURL is required")
+	}
+	if opts.HLSBase == "" {
+		return nil, fmt.Errorf("Streamer2Options.HLSBase is required")
+	}
+	if opts.Duration <= 0 {
+		opts.Duration = 60 * time.Second
+	}
+	if opts.WaitForTarget <= 0 {
+		opts.WaitForTarget = 30 * time.Second
+	}
+	if opts.Writer == nil {
+		opts.Writer = io.Discard
+	}
+	if !strings.HasSuffix(opts.HLSBase, "/") {
+		opts.HLSBase += "/"
+	}
+	return &Streamer2{opts: opts, ctx: ctx}, nil
+}
+
+// Run pushes the source file over RTMP at real-time rate while polling the
+// HLS output for the manifest and new segments, and returns a summary of
+// what it observed.
+func (s *Streamer2) Run() (*Summary, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.opts.Duration+s.opts.WaitForTarget)
+	defer cancel()
+
+	summary := &Summary{}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		pushCtx, pushCancel := context.WithTimeout(ctx, s.opts.Duration)
+		defer pushCancel()
+		bytesPushed, err := s.pushSource(pushCtx)
+		mu.Lock()
+		summary.BytesPushed = bytesPushed
+		summary.FFmpegErr = err
+		mu.Unlock()
+		if err != nil {
+			log.Printf("tester: ffmpeg push ended with error: %v", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.watchOutput(ctx, summary, &mu)
+	}()
+
+	stop := make(chan struct{})
+	go func() {
+		s.report(ctx, summary, &mu)
+		close(stop)
+	}()
+
+	wg.Wait()
+	<-stop
+
+	return summary, nil
+}
+
+// pushSource shells out to ffmpeg to stream the source file to RTMPURL at
+// real-time rate (-re), preserving the source's PTS, and returns the number
+// of bytes it reports having muxed.
+func (s *Streamer2) pushSource(ctx context.Context) (int64, error) {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-re",
+		"-i", s.opts.Source,
+		"-c", "copy",
+		"-f", "flv",
+		s.opts.RTMPURL,
+	)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return 0, fmt.Errorf("StderrPipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("ffmpeg start: %w", err)
+	}
+
+	var bytesPushed int64
+	sizeRe := regexp.MustCompile(`size=\s*(\d+)kB`)
+	scanner := bufio.NewScanner(stderr)
+	// ffmpeg ends its progress lines with '\r', so split on '\r' as well as
+	// '\n' to see every progress update rather than only the first.
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := sizeRe.FindStringSubmatch(line); m != nil {
+			var kb int64
+			fmt.Sscanf(m[1], "%d", &kb)
+			bytesPushed = kb * 1024
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return bytesPushed, nil
+		}
+		return bytesPushed, fmt.Errorf("ffmpeg: %w", err)
+	}
+	return bytesPushed, nil
+}
+
+// watchOutput polls the HLS manifest and records each newly observed
+// segment until ctx is done.
+func (s *Streamer2) watchOutput(ctx context.Context, summary *Summary, mu *sync.Mutex) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	manifestURL := s.opts.HLSBase + "index.m3u8"
+	start := time.Now()
+	seen := make(map[string]bool)
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		body, err := fetchManifest(client, manifestURL)
+		if err != nil {
+			continue
+		}
+
+		mu.Lock()
+		if summary.FirstManifestLatency == 0 {
+			summary.FirstManifestLatency = time.Since(start)
+		}
+		mu.Unlock()
+
+		for _, seg := range parseSegments(body) {
+			if seen[seg] {
+				continue
+			}
+
+			segURL := s.opts.HLSBase + seg
+			if err := headSegment(client, segURL); err != nil {
+				// The manifest listed it before it's actually readable; try
+				// again next poll instead of recording a latency/gap for a
+				// segment that isn't really there yet.
+				log.Printf("tester: segment %s listed but not yet fetchable: %v", seg, err)
+				continue
+			}
+			seen[seg] = true
+			now := time.Now()
+
+			mu.Lock()
+			if len(summary.SegmentsObserved) > 0 {
+				prev := summary.SegmentsObserved[len(summary.SegmentsObserved)-1]
+				summary.Gaps = append(summary.Gaps, now.Sub(prev.ObservedAt))
+			} else {
+				summary.FirstSegmentLatency = now.Sub(start)
+			}
+			summary.SegmentsObserved = append(summary.SegmentsObserved, SegmentObservation{
+				Name:       seg,
+				ObservedAt: now,
+			})
+			mu.Unlock()
+		}
+	}
+}
+
+// fetchManifest HEADs the manifest to confirm it exists, then GETs its body
+// so the segment list can be parsed.
+func fetchManifest(client *http.Client, manifestURL string) (string, error) {
+	headResp, err := client.Head(manifestURL)
+	if err != nil {
+		return "", fmt.Errorf("HEAD %s: %w", manifestURL, err)
+	}
+	headResp.Body.Close()
+	if headResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HEAD %s: status %s", manifestURL, headResp.Status)
+	}
+
+	getResp, err := client.Get(manifestURL)
+	if err != nil {
+		return "", fmt.Errorf("GET %s: %w", manifestURL, err)
+	}
+	defer getResp.Body.Close()
+	body, err := io.ReadAll(getResp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read manifest body: %w", err)
+	}
+	return string(body), nil
+}
+
+// headSegment confirms a newly listed segment is actually fetchable before
+// it's recorded as observed.
+func headSegment(client *http.Client, segmentURL string) error {
+	resp, err := client.Head(segmentURL)
+	if err != nil {
+		return fmt.Errorf("HEAD %s: %w", segmentURL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HEAD %s: status %s", segmentURL, resp.Status)
+	}
+	return nil
+}
+
+// parseSegments extracts .ts segment filenames from an HLS media playlist.
+func parseSegments(manifest string) []string {
+	var segments []string
+	for _, line := range strings.Split(manifest, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasSuffix(line, ".ts") {
+			segments = append(segments, line)
+		}
+	}
+	return segments
+}
+
+// report emits a stats line every second (elapsed time, bytes pushed,
+// segments observed) to opts.Writer until ctx is done. Bytes pushed is set
+// only once the push has ended, so it reads 0 while ffmpeg is running;
+// drift from real time is not reported.
+func (s *Streamer2) report(ctx context.Context, summary *Summary, mu *sync.Mutex) {
+	start := time.Now()
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		mu.Lock()
+		bytesPushed := summary.BytesPushed
+		segments := len(summary.SegmentsObserved)
+		mu.Unlock()
+
+		elapsed := time.Since(start)
+		fmt.Fprintf(s.opts.Writer, "t=%s bytes_pushed=%d segments_observed=%d\n", elapsed.Round(time.Second), bytesPushed, segments)
+	}
+}
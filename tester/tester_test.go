@@ -0,0 +1,45 @@
+package tester
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     []string
+	}{
+		{
+			name: "typical media playlist",
+			manifest: "#EXTM3U\n" +
+				"#EXT-X-VERSION:3\n" +
+				"#EXT-X-TARGETDURATION:6\n" +
+				"#EXTINF:6.000,\n" +
+				"segment_00000.ts\n" +
+				"#EXTINF:6.000,\n" +
+				"segment_00001.ts\n",
+			want: []string{"segment_00000.ts", "segment_00001.ts"},
+		},
+		{
+			name:     "no segments yet",
+			manifest: "#EXTM3U\n#EXT-X-VERSION:3\n",
+			want:     nil,
+		},
+		{
+			name:     "ignores blank lines and non-ts entries",
+			manifest: "#EXTM3U\n\nsegment_00000.ts\n\nindex.m3u8\n",
+			want:     []string{"segment_00000.ts"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseSegments(tt.manifest)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSegments(%q) = %v, want %v", tt.manifest, got, tt.want)
+			}
+		})
+	}
+}